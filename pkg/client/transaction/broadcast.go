@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"time"
+
+	"github.com/calmw/tron-sdk/pkg/proto/api"
+)
+
+// confirmationInitialBackoff is the first delay TxConfirmation waits
+// between polling attempts, doubling from there up to
+// Behavior.ConfirmationCeiling.
+const confirmationInitialBackoff = 500 * time.Millisecond
+
+// BroadcastPolicy configures how SendSignedTx retries a broadcast the node
+// rejected for a retryable reason (a stale ref_block, a busy server)
+// instead of surfacing the error on the first attempt. A duplicate is not
+// retried: SendSignedTx treats DUP_TRANSACTION_ERROR as delivered.
+type BroadcastPolicy struct {
+	// MaxRetries is the number of additional broadcast attempts after the
+	// first one.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry; it doubles on
+	// each subsequent one, capped at MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff between retries.
+	MaxBackoff time.Duration
+	// RefreshOnStaleBlock re-fetches the latest block, rewrites Tx's ref
+	// block and expiration, and re-signs before rebroadcasting when the
+	// node rejects the transaction with TAPOS_ERROR.
+	RefreshOnStaleBlock bool
+}
+
+// DefaultBroadcastPolicy retries a handful of times with exponential
+// backoff and refreshes a stale ref block automatically.
+func DefaultBroadcastPolicy() BroadcastPolicy {
+	return BroadcastPolicy{
+		MaxRetries:          5,
+		InitialBackoff:      500 * time.Millisecond,
+		MaxBackoff:          8 * time.Second,
+		RefreshOnStaleBlock: true,
+	}
+}
+
+// retryable reports whether a broadcast returning code is worth retrying.
+// DUP_TRANSACTION_ERROR is deliberately excluded: it means the node already
+// has this exact transaction (often from an earlier attempt of this same
+// retry loop), not that the broadcast failed, so SendSignedTx treats it as
+// success rather than retrying it here.
+func retryable(code api.Return_response_code) bool {
+	switch code {
+	case api.Return_TAPOS_ERROR, api.Return_SERVER_BUSY:
+		return true
+	default:
+		return false
+	}
+}
+
+// refExpirationWindow is added to the refreshed block's timestamp to
+// compute Tx.RawData.Expiration, matching the window Tron nodes use
+// between ref_block and expiration.
+const refExpirationWindow = 60 * 1000
+
+// refreshRefBlock rewrites C.Tx.RawData's ref block fields and expiration
+// against Client's current block, so a transaction rejected with
+// TAPOS_ERROR (a stale ref_block) can be re-signed and rebroadcast instead
+// of failing outright.
+func (C *Controller) refreshRefBlock() error {
+	block, err := C.Client.GetNowBlock()
+	if err != nil {
+		return err
+	}
+	header := block.GetBlockHeader().GetRawData()
+	blockHash := block.GetBlockid()
+
+	rawData := C.Tx.GetRawData()
+	rawData.RefBlockBytes = []byte{byte(header.Number >> 8), byte(header.Number)}
+	rawData.RefBlockHash = blockHash[8:16]
+	rawData.Expiration = header.Timestamp + refExpirationWindow
+	return nil
+}
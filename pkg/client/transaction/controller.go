@@ -1,12 +1,15 @@
 package transaction
 
 import (
+	"context"
 	"crypto/sha256"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/calmw/tron-sdk/pkg/address"
 	"github.com/calmw/tron-sdk/pkg/client"
+	"github.com/calmw/tron-sdk/pkg/client/transaction/sign"
 	"github.com/calmw/tron-sdk/pkg/common"
 	"github.com/calmw/tron-sdk/pkg/keystore"
 	"github.com/calmw/tron-sdk/pkg/ledger"
@@ -24,6 +27,9 @@ var (
 type sender struct {
 	Ks      *keystore.KeyStore
 	Account *keystore.Account
+	// passphrase is only set when SigningImpl is SoftwarePassphrase. It is
+	// never stored beyond a single SignTxWithPassphraseForSending call.
+	passphrase string
 }
 
 // Controller drives the transaction signing process
@@ -36,23 +42,46 @@ type Controller struct {
 	Behavior       behavior
 	Result         *api.Return
 	Receipt        *core.TransactionInfo
+
+	// approvalQueue, when set via WithApprovalQueue, makes SignTxForSending
+	// enqueue the transaction and block on an external Approve/Discard call
+	// instead of signing inline.
+	approvalQueue *sign.PendingRequests
+
+	// signer binds this Controller to a specific Tron network, so a
+	// transaction built against one network can't be signed and
+	// broadcast against another.
+	signer Signer
 }
 
 type behavior struct {
-	DryRun               bool
-	SigningImpl          SignerImpl
-	ConfirmationWaitTime uint32
+	DryRun      bool
+	SigningImpl SignerImpl
+	// ConfirmationCeiling caps the exponential backoff TxConfirmation uses
+	// while polling for a receipt. Zero means don't poll at all (the old
+	// ConfirmationWaitTime == 0 behavior).
+	ConfirmationCeiling time.Duration
+	// Broadcast configures how SendSignedTx retries a broadcast the node
+	// rejected for a retryable reason.
+	Broadcast BroadcastPolicy
 }
 
-// NewController initializes a Controller, caller can control behavior via options
+// NewController initializes a Controller, caller can control behavior via
+// options. signer binds the controller to the network tx was built
+// against: NewController fetches the client's genesis block and returns an
+// error immediately if it doesn't match signer's chain, rather than
+// handing back a Controller whose every later step silently no-ops.
 func NewController(
 	client *client.GrpcClient,
 	senderKs *keystore.KeyStore,
 	senderAcct *keystore.Account,
 	tx *core.Transaction,
+	signer Signer,
 	options ...func(*Controller),
-) *Controller {
-
+) (*Controller, error) {
+	if err := verifyChain(client, signer); err != nil {
+		return nil, err
+	}
 	ctrlr := &Controller{
 		ExecutionError: nil,
 		resultError:    nil,
@@ -61,19 +90,28 @@ func NewController(
 			Ks:      senderKs,
 			Account: senderAcct,
 		},
-		Tx:       tx,
-		Behavior: behavior{false, Software, 0},
+		Tx:     tx,
+		signer: signer,
+		Behavior: behavior{
+			DryRun:      false,
+			SigningImpl: Software,
+			Broadcast:   DefaultBroadcastPolicy(),
+		},
 	}
 	for _, option := range options {
 		option(ctrlr)
 	}
-	return ctrlr
+	return ctrlr, nil
 }
 
-func (C *Controller) SignTxForSending() {
+func (C *Controller) SignTxForSending(ctx context.Context) {
 	if C.ExecutionError != nil {
 		return
 	}
+	if C.approvalQueue != nil {
+		C.signTxViaApprovalQueue(ctx)
+		return
+	}
 	signedTransaction, err :=
 		C.Sender.Ks.SignTx(*C.Sender.Account, C.Tx)
 	if err != nil {
@@ -83,6 +121,67 @@ func (C *Controller) SignTxForSending() {
 	C.Tx = signedTransaction
 }
 
+// signTxViaApprovalQueue enqueues C.Tx on C.approvalQueue and blocks until a
+// caller resolves it with Approve or Discard, so GUI wallets and RPC
+// servers built on the SDK can prompt a user per-transaction instead of
+// signing inline. ctx cancels the wait if nobody ever calls Approve or
+// Discard.
+func (C *Controller) signTxViaApprovalQueue(ctx context.Context) {
+	id, err := C.TransactionHash()
+	if err != nil {
+		C.ExecutionError = err
+		return
+	}
+	req, err := C.approvalQueue.Add(id, C.Sender.Ks, *C.Sender.Account, C.Tx, transactionMetadata(C.Tx))
+	if err != nil {
+		C.ExecutionError = err
+		return
+	}
+	signedTransaction, err := req.Wait(ctx)
+	if err != nil {
+		C.ExecutionError = err
+		return
+	}
+	C.Tx = signedTransaction
+}
+
+// transactionMetadata summarizes tx's first contract well enough for a UI
+// to render an approval prompt without decoding the raw transaction itself.
+func transactionMetadata(tx *core.Transaction) sign.Metadata {
+	contracts := tx.GetRawData().GetContract()
+	if len(contracts) == 0 {
+		return sign.Metadata{}
+	}
+	c := contracts[0]
+	meta := sign.Metadata{ContractType: c.GetType().String()}
+	if c.GetType() == core.Transaction_Contract_TransferContract {
+		var transfer core.TransferContract
+		if err := proto.Unmarshal(c.GetParameter().GetValue(), &transfer); err == nil {
+			meta.To = address.ToBech32(transfer.GetToAddress())
+			meta.Amount = transfer.GetAmount()
+		}
+	}
+	return meta
+}
+
+// SignTxWithPassphraseForSending signs using the passphrase supplied via
+// WithPassphrase instead of requiring the account to already be unlocked,
+// so server-side consumers never need to hold a long-lived unlocked
+// account in memory.
+func (C *Controller) SignTxWithPassphraseForSending() {
+	if C.ExecutionError != nil {
+		return
+	}
+	signedTransaction, err :=
+		C.Sender.Ks.SignTxWithPassphrase(*C.Sender.Account, C.Sender.passphrase, C.Tx)
+	C.Sender.passphrase = ""
+	if err != nil {
+		C.ExecutionError = err
+		return
+	}
+	C.Tx = signedTransaction
+}
+
 func (C *Controller) HardwareSignTxForSending() {
 	if C.ExecutionError != nil {
 		return
@@ -94,17 +193,15 @@ func (C *Controller) HardwareSignTxForSending() {
 		return
 	}
 
-	/* TODO: validate signature
-	if strings.Compare(signerAddr, address.ToBech32(C.Sender.Account.Address)) != 0 {
+	signerAddr, err := recoverSignerAddress(data, signature)
+	if err != nil {
+		C.ExecutionError = err
+		return
+	}
+	if signerAddr != address.ToBech32(C.Sender.Account.Address) {
 		C.ExecutionError = ErrBadTransactionParam
-		errorMsg := "signature verification failed : Sender address doesn't match with ledger hardware address"
-		C.transactionErrors = append(C.transactionErrors, &Error{
-			ErrMessage:           &errorMsg,
-			TimestampOfRejection: time.Now().Unix(),
-		})
 		return
 	}
-	*/
 	// add signature
 	C.Tx.Signature = append(C.Tx.Signature, signature)
 }
@@ -121,41 +218,43 @@ func (C *Controller) TransactionHash() (string, error) {
 	return common.ToHex(hash), nil
 }
 
-func (C *Controller) TxConfirmation() {
+// TxConfirmation polls for the transaction's receipt, backing off
+// exponentially between attempts up to Behavior.ConfirmationCeiling. ctx
+// cancels the poll instead of the old fixed ConfirmationWaitTime counter.
+func (C *Controller) TxConfirmation(ctx context.Context) {
 	if C.ExecutionError != nil || C.Behavior.DryRun {
 		return
 	}
-	if C.Behavior.ConfirmationWaitTime > 0 {
-		txHash, err := C.TransactionHash()
-		if err != nil {
-			C.ExecutionError = fmt.Errorf("could not get Tx hash")
+	if C.Behavior.ConfirmationCeiling <= 0 {
+		C.Receipt = &core.TransactionInfo{}
+		C.Receipt.Receipt = &core.ResourceReceipt{}
+		return
+	}
+	txHash, err := C.TransactionHash()
+	if err != nil {
+		C.ExecutionError = fmt.Errorf("could not get Tx hash")
+		return
+	}
+	backoff := confirmationInitialBackoff
+	for {
+		if txi, err := C.Client.GetTransactionInfoByID(txHash); err == nil {
+			if txi.Result != 0 {
+				C.resultError = fmt.Errorf("%s", txi.ResMessage)
+			}
+			C.Receipt = txi
 			return
 		}
-		//fmt.Printf("TX hash: %s\nWaiting for confirmation....", txHash)
-		start := int(C.Behavior.ConfirmationWaitTime)
-		for {
-			// GETTX by ID
-			if txi, err := C.Client.GetTransactionInfoByID(txHash); err == nil {
-				// check receipt
-				if txi.Result != 0 {
-					C.resultError = fmt.Errorf("%s", txi.ResMessage)
-				}
-				// Add receipt
-				C.Receipt = txi
-				return
-			}
-			if start < 0 {
-				C.ExecutionError = fmt.Errorf("could not confirm transaction after %d seconds", C.Behavior.ConfirmationWaitTime)
-				return
-			}
-			time.Sleep(time.Second)
-			start--
+		select {
+		case <-ctx.Done():
+			C.ExecutionError = fmt.Errorf("could not confirm transaction: %w", ctx.Err())
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > C.Behavior.ConfirmationCeiling {
+			backoff = C.Behavior.ConfirmationCeiling
 		}
-	} else {
-		C.Receipt = &core.TransactionInfo{}
-		C.Receipt.Receipt = &core.ResourceReceipt{}
 	}
-
 }
 
 // GetResultError return result error
@@ -165,17 +264,34 @@ func (C *Controller) GetResultError() error {
 
 // ExecuteTransaction is the single entrypoint to execute a plain transaction.
 // Each step in transaction creation, execution probably includes a mutation
-// Each becomes a no-op if ExecutionError occurred in any previous step
-func (C *Controller) ExecuteTransaction() error {
+// Each becomes a no-op if ExecutionError occurred in any previous step.
+// ctx bounds broadcast retries and confirmation polling; with
+// context.Background(), retries are bounded only by
+// Behavior.Broadcast.MaxRetries and polling continues until a receipt is
+// found.
+func (C *Controller) ExecuteTransaction(ctx context.Context) error {
+	C.sign(ctx)
+	C.SendSignedTx(ctx)
+	C.TxConfirmation(ctx)
+	return C.ExecutionError
+}
+
+// sign dispatches to the signing path selected by Behavior.SigningImpl. It
+// is also used to re-sign a transaction whose ref block SendSignedTx just
+// refreshed after a TAPOS_ERROR. ctx only matters for Software, which may
+// block on an approval queue.
+func (C *Controller) sign(ctx context.Context) {
 	switch C.Behavior.SigningImpl {
 	case Software:
-		C.SignTxForSending()
+		C.SignTxForSending(ctx)
 	case Ledger:
 		C.HardwareSignTxForSending()
+	case External:
+		// Signing already happened out-of-process; AttachSignature must
+		// have been called before ExecuteTransaction.
+	case SoftwarePassphrase:
+		C.SignTxWithPassphraseForSending()
 	}
-	C.SendSignedTx()
-	C.TxConfirmation()
-	return C.ExecutionError
 }
 
 // GetRawData Byes from Transaction
@@ -183,17 +299,72 @@ func (C *Controller) GetRawData() ([]byte, error) {
 	return proto.Marshal(C.Tx.GetRawData())
 }
 
-func (C *Controller) SendSignedTx() {
+// SendSignedTx broadcasts C.Tx, retrying according to Behavior.Broadcast
+// when the node returns a retryable error. On TAPOS_ERROR with
+// RefreshOnStaleBlock set, it refreshes C.Tx's ref block against the
+// latest block, clears the now-stale signature, re-signs, and rebroadcasts
+// rather than giving up. This only works for Software and Ledger, which
+// the controller can re-drive on demand. External has no key here to
+// re-sign with, and SoftwarePassphrase already zeroed its passphrase after
+// the first sign (see SignTxWithPassphraseForSending) rather than holding
+// it for the controller's lifetime, so both surface the TAPOS_ERROR
+// immediately instead of claiming a refresh that can't happen.
+func (C *Controller) SendSignedTx(ctx context.Context) {
 	if C.ExecutionError != nil || C.Behavior.DryRun {
 		return
 	}
-	result, err := C.Client.Broadcast(C.Tx)
-	if err != nil {
-		C.ExecutionError = err
-		return
-	}
-	if result.Code != 0 {
-		C.ExecutionError = fmt.Errorf("bad transaction: %v", string(result.GetMessage()))
+	policy := C.Behavior.Broadcast
+	backoff := policy.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		result, err := C.Client.Broadcast(C.Tx)
+		if err != nil {
+			C.ExecutionError = err
+			return
+		}
+		if result.Code == api.Return_SUCCESS || result.Code == api.Return_DUP_TRANSACTION_ERROR {
+			// DUP_TRANSACTION_ERROR means the node already has this exact
+			// transaction, most likely from an earlier attempt of this
+			// same retry loop, so treat it as delivered rather than
+			// retrying or reporting "bad transaction".
+			C.Result = result
+			return
+		}
+		if attempt >= policy.MaxRetries || !retryable(result.Code) {
+			C.ExecutionError = fmt.Errorf("bad transaction: %v", string(result.GetMessage()))
+			C.Result = result
+			return
+		}
+		if result.Code == api.Return_TAPOS_ERROR && policy.RefreshOnStaleBlock {
+			if C.Behavior.SigningImpl == External || C.Behavior.SigningImpl == SoftwarePassphrase {
+				// External has no key here to re-sign with.
+				// SoftwarePassphrase already zeroed its passphrase after
+				// the first sign rather than holding it for the
+				// controller's lifetime. Either way, refreshing would
+				// just rebroadcast the same now-invalid signature.
+				// Surface the error instead of retrying forever.
+				C.ExecutionError = fmt.Errorf("bad transaction: %v", string(result.GetMessage()))
+				C.Result = result
+				return
+			}
+			if err := C.refreshRefBlock(); err != nil {
+				C.ExecutionError = err
+				return
+			}
+			C.Tx.Signature = nil
+			C.sign(ctx)
+			if C.ExecutionError != nil {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			C.ExecutionError = ctx.Err()
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
 	}
-	C.Result = result
 }
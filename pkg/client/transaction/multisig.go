@@ -0,0 +1,228 @@
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/calmw/tron-sdk/pkg/address"
+	"github.com/calmw/tron-sdk/pkg/client"
+	"github.com/calmw/tron-sdk/pkg/proto/core"
+	"google.golang.org/protobuf/encoding/protojson"
+	proto "google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrPermissionNotFound is returned when the sender account has no
+	// permission matching the multisig controller's PermissionID (0 selects
+	// the owner permission, any other ID an active permission), or when a
+	// signature comes from a key that permission does not list.
+	ErrPermissionNotFound = errors.New("sender has no matching permission")
+	// ErrDuplicateSignature is returned when the same key signs a
+	// multisig transaction more than once.
+	ErrDuplicateSignature = errors.New("address already contributed a signature")
+	// ErrThresholdNotMet is returned when Broadcast is called before the
+	// accumulated signature weight reaches the permission's threshold.
+	ErrThresholdNotMet = errors.New("accumulated signature weight below threshold")
+)
+
+// MultiSigController coordinates several independently held keys signing
+// the same transaction under a Tron permission. Unlike Controller, it never
+// holds more than one signer's key at a time: each contributor calls
+// AddSignature with a signature they produced themselves, and the
+// controller only verifies it against the on-chain permission.
+type MultiSigController struct {
+	Client       *client.GrpcClient
+	Tx           *core.Transaction
+	Sender       address.Address
+	PermissionID int32
+
+	permission *core.Permission
+	contribs   map[string][]byte // bech32 address -> signature
+	signer     Signer
+}
+
+// NewMultiSigController fetches the permission set for sender from the
+// chain and returns a controller ready to accumulate signatures for tx
+// under permissionID (0 is the owner permission, as per Tron's
+// permission-based multisig model). signer binds the controller to cli's
+// network, the same check NewController runs, since Broadcast constructs
+// its own Controller directly instead of going through NewController.
+func NewMultiSigController(cli *client.GrpcClient, sender address.Address, permissionID int32, tx *core.Transaction, signer Signer) (*MultiSigController, error) {
+	if err := verifyChain(cli, signer); err != nil {
+		return nil, err
+	}
+	acc, err := cli.GetAccount(address.ToBech32(sender))
+	if err != nil {
+		return nil, err
+	}
+	perm := findPermission(acc, permissionID)
+	if perm == nil {
+		return nil, ErrPermissionNotFound
+	}
+	return &MultiSigController{
+		Client:       cli,
+		Tx:           tx,
+		Sender:       sender,
+		PermissionID: permissionID,
+		permission:   perm,
+		contribs:     make(map[string][]byte),
+		signer:       signer,
+	}, nil
+}
+
+func findPermission(acc *core.Account, permissionID int32) *core.Permission {
+	if permissionID == 0 {
+		return acc.GetOwnerPermission()
+	}
+	for _, p := range acc.GetActivePermission() {
+		if p.GetId() == permissionID {
+			return p
+		}
+	}
+	return nil
+}
+
+// AddSignature verifies sig against the transaction sighash and the
+// permission's key list, then records it as signerAddr's contribution.
+// Signatures from keys not present in the permission, or duplicate
+// contributions from the same address, are rejected.
+func (M *MultiSigController) AddSignature(signerAddr address.Address, sig []byte) error {
+	rawData, err := proto.Marshal(M.Tx.GetRawData())
+	if err != nil {
+		return err
+	}
+	recovered, err := recoverSignerAddress(rawData, sig)
+	if err != nil {
+		return err
+	}
+	if recovered != address.ToBech32(signerAddr) {
+		return ErrBadTransactionParam
+	}
+	if !M.hasKey(signerAddr) {
+		return ErrPermissionNotFound
+	}
+	bech32 := address.ToBech32(signerAddr)
+	if _, ok := M.contribs[bech32]; ok {
+		return ErrDuplicateSignature
+	}
+	M.contribs[bech32] = sig
+	return nil
+}
+
+func (M *MultiSigController) hasKey(addr address.Address) bool {
+	for _, k := range M.permission.GetKeys() {
+		if bytes.Equal(k.GetAddress(), addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Weight returns the combined weight of signatures contributed so far.
+func (M *MultiSigController) Weight() int64 {
+	var total int64
+	for bech32 := range M.contribs {
+		for _, k := range M.permission.GetKeys() {
+			if address.ToBech32(k.GetAddress()) == bech32 {
+				total += k.GetWeight()
+			}
+		}
+	}
+	return total
+}
+
+// ThresholdMet reports whether enough weight has been accumulated to
+// broadcast the transaction.
+func (M *MultiSigController) ThresholdMet() bool {
+	return M.Weight() >= M.permission.GetThreshold()
+}
+
+// Broadcast assembles the collected signatures onto Tx and sends it,
+// refusing to do so until ThresholdMet reports true. ctx bounds the
+// underlying broadcast retries. The returned Controller's
+// Behavior.ConfirmationCeiling is zero, so no receipt is polled for and its
+// Receipt is left empty.
+func (M *MultiSigController) Broadcast(ctx context.Context) (*Controller, error) {
+	if !M.ThresholdMet() {
+		return nil, ErrThresholdNotMet
+	}
+	// Assemble into a fresh slice rather than appending onto M.Tx.Signature
+	// directly, so calling Broadcast more than once (a retry from the
+	// caller's side, say) doesn't duplicate every contributor's signature.
+	signatures := make([][]byte, 0, len(M.contribs))
+	for _, sig := range M.contribs {
+		signatures = append(signatures, sig)
+	}
+	M.Tx.Signature = signatures
+	ctrlr := &Controller{
+		Client: M.Client,
+		Tx:     M.Tx,
+		signer: M.signer,
+		Behavior: behavior{
+			DryRun:      false,
+			SigningImpl: External,
+			Broadcast:   DefaultBroadcastPolicy(),
+		},
+	}
+	ctrlr.SendSignedTx(ctx)
+	ctrlr.TxConfirmation(ctx)
+	return ctrlr, ctrlr.ExecutionError
+}
+
+// partialSignedTx is the wire format used to ship a partially-signed
+// multisig transaction between contributors. Tx is protojson-encoded
+// rather than handed to encoding/json directly: core.Transaction is a
+// protobuf message, and json.Marshal doesn't understand its oneof/Any
+// fields or wire semantics.
+type partialSignedTx struct {
+	Tx           json.RawMessage   `json:"tx"`
+	PermissionID int32             `json:"permission_id"`
+	Sender       string            `json:"sender"`
+	Contribs     map[string][]byte `json:"contributions"`
+}
+
+// MarshalJSON serializes the controller's current state, including
+// signatures gathered so far, so it can be shipped to the next signer.
+func (M *MultiSigController) MarshalJSON() ([]byte, error) {
+	txJSON, err := protojson.Marshal(M.Tx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(partialSignedTx{
+		Tx:           txJSON,
+		PermissionID: M.PermissionID,
+		Sender:       address.ToBech32(M.Sender),
+		Contribs:     M.contribs,
+	})
+}
+
+// UnmarshalPartialSignedTx reconstructs a MultiSigController from JSON
+// produced by MarshalJSON, re-fetching the permission from cli so the next
+// contributor can keep validating against the live on-chain state. signer
+// binds the reconstructed controller to cli's network, same as
+// NewMultiSigController.
+func UnmarshalPartialSignedTx(cli *client.GrpcClient, data []byte, signer Signer) (*MultiSigController, error) {
+	var p partialSignedTx
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	var tx core.Transaction
+	if err := protojson.Unmarshal(p.Tx, &tx); err != nil {
+		return nil, err
+	}
+	sender, err := address.Base58ToAddress(p.Sender)
+	if err != nil {
+		return nil, err
+	}
+	M, err := NewMultiSigController(cli, sender, p.PermissionID, &tx, signer)
+	if err != nil {
+		return nil, err
+	}
+	if p.Contribs == nil {
+		p.Contribs = make(map[string][]byte)
+	}
+	M.contribs = p.Contribs
+	return M, nil
+}
@@ -0,0 +1,122 @@
+package transaction
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/calmw/tron-sdk/pkg/address"
+	"github.com/calmw/tron-sdk/pkg/client"
+	"github.com/calmw/tron-sdk/pkg/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SendArgs describes the transaction a caller wants built for offline
+// signing, mirroring the minimal field set status-go's wallet API uses for
+// SendTxArgs.
+type SendArgs struct {
+	From   string
+	To     string
+	Amount int64
+}
+
+// BuildUnsigned finalizes C.Tx.RawData and returns the canonical raw bytes
+// alongside their hex-encoded sha256 sighash, so the transaction can be
+// handed off to an offline signer (a hardware wallet, an HSM, a
+// WalletConnect session, ...) instead of being signed in-process.
+func (C *Controller) BuildUnsigned() ([]byte, string, error) {
+	if C.ExecutionError != nil {
+		return nil, "", C.ExecutionError
+	}
+	rawData, err := C.GetRawData()
+	if err != nil {
+		C.ExecutionError = err
+		return nil, "", err
+	}
+	sighash, err := C.TransactionHash()
+	if err != nil {
+		C.ExecutionError = err
+		return nil, "", err
+	}
+	return rawData, sighash, nil
+}
+
+// AttachSignature validates a signature produced by an external signer and
+// attaches it to C.Tx. It recovers the signer address from the sha256
+// sighash and checks it against Sender.Account.Address, so the controller
+// never has to see the private key that produced it.
+func (C *Controller) AttachSignature(signature []byte) error {
+	if C.ExecutionError != nil {
+		return C.ExecutionError
+	}
+	if len(signature) != 65 {
+		err := fmt.Errorf("invalid signature length: got %d, want 65", len(signature))
+		C.ExecutionError = err
+		return err
+	}
+	rawData, err := C.GetRawData()
+	if err != nil {
+		C.ExecutionError = err
+		return err
+	}
+	signerAddr, err := recoverSignerAddress(rawData, signature)
+	if err != nil {
+		C.ExecutionError = err
+		return err
+	}
+	if signerAddr != address.ToBech32(C.Sender.Account.Address) {
+		C.ExecutionError = ErrBadTransactionParam
+		return ErrBadTransactionParam
+	}
+	C.Tx.Signature = append(C.Tx.Signature, signature)
+	return nil
+}
+
+// recoverSignerAddress recovers the bech32-encoded address that produced
+// signature over rawData's sha256 sighash.
+func recoverSignerAddress(rawData, signature []byte) (string, error) {
+	hash := sha256.Sum256(rawData)
+	pubKey, err := crypto.SigToPub(hash[:], signature)
+	if err != nil {
+		return "", err
+	}
+	return address.ToBech32(address.PubkeyToAddress(*pubKey)), nil
+}
+
+// BuildRawTransaction assembles a Controller for args, ready for broadcast,
+// from a signature an external signer already produced over the sighash
+// returned by a prior BuildUnsigned call. It mirrors the pattern status-go's
+// wallet API uses for WalletConnect / hardware wallet / HSM integrations: the
+// SDK builds the transaction and verifies the signature, but never handles
+// the private key. signer binds the built transaction to cli's network, the
+// same check NewController runs, since this path constructs its Controller
+// directly instead of going through NewController.
+func BuildRawTransaction(cli *client.GrpcClient, args SendArgs, signature []byte, signer Signer) (*Controller, error) {
+	if err := verifyChain(cli, signer); err != nil {
+		return nil, err
+	}
+	tx, err := cli.Transfer(args.From, args.To, args.Amount)
+	if err != nil {
+		return nil, err
+	}
+	fromAddr, err := address.Base58ToAddress(args.From)
+	if err != nil {
+		return nil, err
+	}
+	ctrlr := &Controller{
+		Client: cli,
+		Tx:     tx.Transaction,
+		Sender: sender{
+			Account: &keystore.Account{Address: fromAddr},
+		},
+		signer: signer,
+		Behavior: behavior{
+			DryRun:      false,
+			SigningImpl: External,
+			Broadcast:   DefaultBroadcastPolicy(),
+		},
+	}
+	if err := ctrlr.AttachSignature(signature); err != nil {
+		return nil, err
+	}
+	return ctrlr, nil
+}
@@ -0,0 +1,24 @@
+package transaction
+
+import "github.com/calmw/tron-sdk/pkg/client/transaction/sign"
+
+// WithApprovalQueue makes the Controller enqueue transactions on q instead
+// of signing them inline, so the caller can approve or discard them
+// asynchronously (see the sign package).
+func WithApprovalQueue(q *sign.PendingRequests) func(*Controller) {
+	return func(c *Controller) {
+		c.approvalQueue = q
+	}
+}
+
+// WithPassphrase sets the passphrase SignTxWithPassphraseForSending uses to
+// decrypt the sender's keystore account, and selects SoftwarePassphrase as
+// the controller's signing path. Without it, a caller could set the
+// passphrase and still have the default Software path try to sign with an
+// already-unlocked account, failing or silently using the wrong key.
+func WithPassphrase(pw string) func(*Controller) {
+	return func(c *Controller) {
+		c.Sender.passphrase = pw
+		c.Behavior.SigningImpl = SoftwarePassphrase
+	}
+}
@@ -0,0 +1,154 @@
+// Package sign models signing as an asynchronous approval step, so GUI
+// wallets and RPC servers built on the SDK can prompt a user per-transaction
+// instead of signing inline, mirroring status-go's sign.PendingRequests.
+package sign
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/calmw/tron-sdk/pkg/keystore"
+	"github.com/calmw/tron-sdk/pkg/proto/core"
+)
+
+var (
+	// ErrRequestNotFound is returned when Approve or Discard is called
+	// with an ID that is not (or no longer) pending.
+	ErrRequestNotFound = errors.New("sign: request not found")
+	// ErrRequestDiscarded is returned to a caller waiting on a request
+	// that was discarded instead of approved.
+	ErrRequestDiscarded = errors.New("sign: request discarded")
+)
+
+// Metadata describes a pending request well enough for a UI to render an
+// approval prompt without decoding the raw transaction.
+type Metadata struct {
+	ContractType string
+	To           string
+	Amount       int64
+}
+
+// Request is a transaction awaiting approval, keyed by the sha256 sighash
+// of its raw data.
+type Request struct {
+	ID       string
+	Ks       *keystore.KeyStore
+	Account  keystore.Account
+	Tx       *core.Transaction
+	Metadata Metadata
+
+	registry *PendingRequests
+	result   chan Result
+}
+
+// Result is delivered to whoever is waiting on a Request once it is
+// resolved, either by Approve or Discard.
+type Result struct {
+	Tx  *core.Transaction
+	Err error
+}
+
+// PendingRequests is a registry of transactions waiting for a caller to
+// approve or discard them, keyed by sighash.
+type PendingRequests struct {
+	mu       sync.Mutex
+	requests map[string]*Request
+}
+
+// NewPendingRequests returns an empty registry.
+func NewPendingRequests() *PendingRequests {
+	return &PendingRequests{
+		requests: make(map[string]*Request),
+	}
+}
+
+// Add registers tx under id and returns the Request a caller can wait on
+// via Wait. It is an error to Add the same id twice while it is pending.
+func (p *PendingRequests) Add(id string, ks *keystore.KeyStore, account keystore.Account, tx *core.Transaction, meta Metadata) (*Request, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.requests[id]; ok {
+		return nil, errors.New("sign: request already pending")
+	}
+	req := &Request{
+		ID:       id,
+		Ks:       ks,
+		Account:  account,
+		Tx:       tx,
+		Metadata: meta,
+		registry: p,
+		result:   make(chan Result, 1),
+	}
+	p.requests[id] = req
+	return req, nil
+}
+
+// Wait blocks until req is approved or discarded,
+// or ctx is done. On cancellation, the request is removed from the
+// registry so a late Approve/Discard call fails with ErrRequestNotFound
+// instead of silently resolving a wait nobody is listening on anymore.
+func (req *Request) Wait(ctx context.Context) (*core.Transaction, error) {
+	select {
+	case res := <-req.result:
+		return res.Tx, res.Err
+	case <-ctx.Done():
+		req.registry.cancel(req.ID)
+		return nil, ctx.Err()
+	}
+}
+
+// Approve signs the pending request identified by id using password,
+// unlocking the account only for the duration of signing (mirroring
+// go-ethereum's Manager.SignWithPassphrase) and re-locking it immediately
+// after, then unblocks whoever is waiting on it via Request.Wait.
+func (p *PendingRequests) Approve(id string, password string) error {
+	req, err := p.take(id)
+	if err != nil {
+		return err
+	}
+	if err := req.Ks.Unlock(req.Account, password); err != nil {
+		req.result <- Result{Err: err}
+		return err
+	}
+	defer req.Ks.Lock(req.Account.Address)
+	signed, err := req.Ks.SignTx(req.Account, req.Tx)
+	if err != nil {
+		req.result <- Result{Err: err}
+		return err
+	}
+	req.result <- Result{Tx: signed}
+	return nil
+}
+
+// Discard resolves the pending request identified by id with
+// ErrRequestDiscarded, so the blocked caller returns an error instead of a
+// signed transaction.
+func (p *PendingRequests) Discard(id string) error {
+	req, err := p.take(id)
+	if err != nil {
+		return err
+	}
+	req.result <- Result{Err: ErrRequestDiscarded}
+	return nil
+}
+
+func (p *PendingRequests) take(id string) (*Request, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	req, ok := p.requests[id]
+	if !ok {
+		return nil, ErrRequestNotFound
+	}
+	delete(p.requests, id)
+	return req, nil
+}
+
+// cancel removes id from the registry without resolving it, used when a
+// waiter gives up via a canceled context instead of an Approve/Discard
+// call.
+func (p *PendingRequests) cancel(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.requests, id)
+}
@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/calmw/tron-sdk/pkg/client"
+	"github.com/calmw/tron-sdk/pkg/common"
+	"github.com/calmw/tron-sdk/pkg/proto/core"
+	proto "google.golang.org/protobuf/proto"
+)
+
+// Signer binds a Controller to a specific Tron network, the way EIP-155's
+// chain ID binds an Ethereum transaction to one. Without it, a transaction
+// built for one network could be mis-broadcast against another by simply
+// swapping the Client.
+type Signer interface {
+	// Hash returns the sha256 sighash that must be signed for tx.
+	Hash(tx *core.Transaction) ([]byte, error)
+	// ChainID returns the genesis block id identifying the network this
+	// signer is bound to.
+	ChainID() []byte
+}
+
+type chainSigner struct {
+	genesisHash []byte
+}
+
+// NewSigner returns a Signer bound to the network whose genesis block id
+// is genesisHash.
+func NewSigner(genesisHash []byte) Signer {
+	return &chainSigner{genesisHash: genesisHash}
+}
+
+func (s *chainSigner) Hash(tx *core.Transaction) ([]byte, error) {
+	rawData, err := proto.Marshal(tx.GetRawData())
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(rawData)
+	return hash[:], nil
+}
+
+func (s *chainSigner) ChainID() []byte {
+	return s.genesisHash
+}
+
+// Well-known genesis block ids for the public Tron networks, so
+// applications don't have to look them up by hand.
+var (
+	mainnetGenesisHash = common.FromHex("0x0000000000000000753811a7f13e1f7582efa47dd4b1a7d5e94377e5d66bc90a")
+	nileGenesisHash    = common.FromHex("0x0000000000000000436e1758351c752b91317c4de5664cccd45280a8e61f3dd4")
+	shastaGenesisHash  = common.FromHex("0x00000000000000005273919215929fe5a0b738958826f9078ad425c9531d274a")
+)
+
+// MainnetSigner returns a Signer bound to Tron Mainnet.
+func MainnetSigner() Signer { return NewSigner(mainnetGenesisHash) }
+
+// NileSigner returns a Signer bound to the Nile testnet.
+func NileSigner() Signer { return NewSigner(nileGenesisHash) }
+
+// ShastaSigner returns a Signer bound to the Shasta testnet.
+func ShastaSigner() Signer { return NewSigner(shastaGenesisHash) }
+
+// verifyChain fetches the genesis block (block 0) from cli and confirms it
+// matches signer's expected chain. Tron's ref_block fields are derived from
+// a recent block, not the genesis block, so they can't be cross-checked
+// against a signer's chain ID directly; checking the Client's own genesis
+// block is what actually prevents a transaction from being signed and
+// broadcast against the wrong network.
+func verifyChain(cli *client.GrpcClient, signer Signer) error {
+	genesis, err := cli.GetBlockByNum(0)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(genesis.GetBlockid(), signer.ChainID()) {
+		return ErrBadTransactionParam
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package transaction
+
+// SignerImpl identifies which mechanism is responsible for producing the
+// signature attached to a Controller's transaction.
+type SignerImpl int
+
+const (
+	// Software signs the transaction using the local, unlocked keystore
+	// account held on Controller.Sender. With WithApprovalQueue set, it
+	// instead enqueues the transaction and waits for Approve, which unlocks
+	// the account only while signing.
+	Software SignerImpl = iota
+	// Ledger signs the transaction using an attached Ledger hardware wallet.
+	Ledger
+	// External expects the signature to already have been produced outside
+	// of the controller (WalletConnect, an HSM, a hardware wallet, an
+	// offline signer, ...) and attached via Controller.AttachSignature.
+	// ExecuteTransaction skips internal signing entirely for this impl.
+	External
+	// SoftwarePassphrase signs the transaction by decrypting the keystore
+	// account with the passphrase supplied via WithPassphrase, without
+	// requiring the account to already be unlocked.
+	SoftwarePassphrase
+)
@@ -0,0 +1,33 @@
+package keystore
+
+import (
+	"crypto/sha256"
+
+	"github.com/calmw/tron-sdk/pkg/proto/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	proto "google.golang.org/protobuf/proto"
+)
+
+// SignTxWithPassphrase signs tx with the private key decrypted from a's
+// keyfile using passphrase, without requiring a to already be unlocked. The
+// decrypted key is zeroed immediately after signing, mirroring
+// go-ethereum's Manager.SignWithPassphrase.
+func (ks *KeyStore) SignTxWithPassphrase(a Account, passphrase string, tx *core.Transaction) (*core.Transaction, error) {
+	_, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key.PrivateKey)
+
+	rawData, err := proto.Marshal(tx.GetRawData())
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(rawData)
+	signature, err := crypto.Sign(hash[:], key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	tx.Signature = append(tx.Signature, signature)
+	return tx, nil
+}